@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// CacheBackend is the storage and counters behind Cache. Cache itself only
+// implements the stale-while-revalidate orchestration (singleflight-backed
+// background refresh); everything about where entries and stats actually
+// live is delegated here so replicas can share state via Redis instead of
+// each holding its own in-memory map.
+type CacheBackend interface {
+	// Get returns the entry for key, if present. Expiry is the caller's
+	// concern (Cache compares FreshUntil/StaleUntil itself).
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+	// Keys lists every key known to the backend, used by the cache warmer
+	// to iterate known (platform, channel, offset) tuples.
+	Keys() []string
+	// Cleanup removes entries past their StaleUntil horizon and returns how
+	// many were removed.
+	Cleanup() int
+
+	RecordHit()
+	RecordMiss()
+	RecordStaleServed()
+	RecordBackgroundRefresh()
+	RecordAPISuccess()
+	RecordAPIError(err error)
+
+	Stats() CacheStats
+	IsAPIHealthy() bool
+	APIStatus() string
+	LastAPICall() time.Time
+	LastAPIError() string
+}
+
+// getCacheBackendName returns the configured CACHE_BACKEND value, default
+// "memory".
+func getCacheBackendName() string {
+	if os.Getenv("CACHE_BACKEND") == "redis" {
+		return "redis"
+	}
+	return "memory"
+}
+
+// newCacheBackend builds the configured CacheBackend. CACHE_BACKEND selects
+// "memory" (default) or "redis"; REDIS_ADDR/REDIS_PASSWORD/REDIS_DB
+// configure the latter.
+func newCacheBackend() CacheBackend {
+	if getCacheBackendName() == "redis" {
+		return newRedisBackend()
+	}
+	return newMemoryBackend()
+}
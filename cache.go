@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// freshTTL is how long an entry is served without triggering a background
+// refresh. staleTTL is the outer bound past which a request blocks on a
+// synchronous fetch instead of being served a cached value.
+const (
+	freshTTL = 1 * time.Hour
+	staleTTL = 24 * time.Hour
+)
+
+const defaultCacheWarmInterval = 15 * time.Minute
+
+// CacheStats represents cache statistics
+type CacheStats struct {
+	TotalEntries           int            `json:"total_entries"`
+	ActiveEntries          int            `json:"active_entries"`
+	ExpiredEntries         int            `json:"expired_entries"`
+	HitRate                string         `json:"hit_rate,omitempty"`
+	ByChannel              map[string]int `json:"by_channel,omitempty"`
+	StaleServedTotal       int64          `json:"stale_served_total"`
+	BackgroundRefreshTotal int64          `json:"background_refresh_total"`
+}
+
+// CacheEntry represents a cached response along with the request
+// parameters that produced it (so the cache can refresh itself) and the
+// two expiry horizons used for stale-while-revalidate serving.
+type CacheEntry struct {
+	Response   VersionResponse
+	Platform   string
+	Channel    string
+	Offset     int
+	FreshUntil time.Time
+	StaleUntil time.Time
+}
+
+// Cache implements stale-while-revalidate serving on top of a pluggable
+// CacheBackend: it decides when an entry is fresh, stale-but-servable, or
+// needs a blocking fetch, and deduplicates background refreshes via
+// singleflight. Where entries and counters actually live is the backend's
+// concern, see CacheBackend.
+type Cache struct {
+	backend      CacheBackend
+	refreshGroup singleflight.Group
+}
+
+// Global cache instance
+var cache = &Cache{backend: newCacheBackend()}
+
+// getCacheWarmInterval reads CACHE_WARM_INTERVAL (a Go duration string,
+// e.g. "15m") from env, default 15 minutes.
+func getCacheWarmInterval() time.Duration {
+	raw := os.Getenv("CACHE_WARM_INTERVAL")
+	if raw == "" {
+		return defaultCacheWarmInterval
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return defaultCacheWarmInterval
+	}
+	return d
+}
+
+// Get retrieves a cached entry if it hasn't passed StaleUntil. Entries
+// past FreshUntil are still returned, but trigger a singleflight-protected
+// background refresh first.
+func (c *Cache) Get(key string) (VersionResponse, bool) {
+	entry, found := c.backend.Get(key)
+	if !found {
+		return VersionResponse{}, false
+	}
+
+	now := time.Now()
+	if now.After(entry.StaleUntil) {
+		return VersionResponse{}, false
+	}
+
+	if now.After(entry.FreshUntil) {
+		c.backend.RecordStaleServed()
+		go c.refresh(key, entry)
+	}
+
+	return entry.Response, true
+}
+
+// Set stores a response in the cache, resetting its fresh/stale horizons.
+func (c *Cache) Set(key, platform, channel string, offset int, response VersionResponse) {
+	now := time.Now()
+	c.backend.Set(key, CacheEntry{
+		Response:   response,
+		Platform:   platform,
+		Channel:    channel,
+		Offset:     offset,
+		FreshUntil: now.Add(freshTTL),
+		StaleUntil: now.Add(staleTTL),
+	})
+}
+
+// refresh re-fetches a single entry from Google, deduplicating concurrent
+// refreshes of the same key via singleflight.
+func (c *Cache) refresh(key string, entry CacheEntry) {
+	_, _, _ = c.refreshGroup.Do(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), retryMaxElapsedTime)
+		defer cancel()
+
+		response, err := fetchVersionFromGoogle(ctx, entry.Platform, entry.Channel, entry.Offset)
+		if err != nil {
+			log.Printf("Background refresh failed for %s: %v", key, err)
+			return nil, err
+		}
+
+		if response.LatestAccepted != entry.Response.LatestAccepted {
+			notifier.Publish(VersionChangeEvent{
+				Platform:   entry.Platform,
+				Channel:    entry.Channel,
+				Offset:     entry.Offset,
+				Previous:   entry.Response.LatestAccepted,
+				Current:    response.LatestAccepted,
+				DetectedAt: time.Now().Format(time.RFC3339),
+			})
+		}
+
+		c.Set(key, entry.Platform, entry.Channel, entry.Offset, response)
+		c.backend.RecordBackgroundRefresh()
+		log.Printf("Background refresh succeeded for %s", key)
+		return response, nil
+	})
+}
+
+// warmLoop periodically refreshes every known cache entry so that
+// production traffic on the request path essentially never has to wait on
+// a synchronous Google API call. It stops cleanly when ctx is canceled.
+func (c *Cache) warmLoop(ctx context.Context) {
+	ticker := time.NewTicker(getCacheWarmInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, key := range c.backend.Keys() {
+				entry, found := c.backend.Get(key)
+				if !found {
+					continue
+				}
+				go c.refresh(key, entry)
+			}
+		}
+	}
+}
+
+// cleanupExpiredCache removes entries past StaleUntil every hour. It stops
+// cleanly when ctx is canceled.
+func cleanupExpiredCache(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count := cache.backend.Cleanup()
+			if count > 0 {
+				for i := 0; i < count; i++ {
+					cacheEventsTotal.WithLabelValues("evict").Inc()
+				}
+				log.Printf("Cache cleanup: removed %d expired entries", count)
+			}
+		}
+	}
+}
+
+// recordHit increments cache hit counter
+func (c *Cache) recordHit() {
+	c.backend.RecordHit()
+	cacheEventsTotal.WithLabelValues("hit").Inc()
+}
+
+// recordMiss increments cache miss counter
+func (c *Cache) recordMiss() {
+	c.backend.RecordMiss()
+	cacheEventsTotal.WithLabelValues("miss").Inc()
+}
+
+// recordAPISuccess marks the API as healthy
+func (c *Cache) recordAPISuccess() {
+	c.backend.RecordAPISuccess()
+}
+
+// recordAPIError marks the API as unhealthy
+func (c *Cache) recordAPIError(err error) {
+	c.backend.RecordAPIError(err)
+	googleAPIErrorsTotal.Inc()
+}
+
+// isAPIHealthy returns the current API health status
+func (c *Cache) isAPIHealthy() bool {
+	return c.backend.IsAPIHealthy()
+}
+
+// getAPIStatus returns a human-readable API status
+func (c *Cache) getAPIStatus() string {
+	return c.backend.APIStatus()
+}
+
+// lastAPICall returns the time of the most recent Google API call
+func (c *Cache) lastAPICall() time.Time {
+	return c.backend.LastAPICall()
+}
+
+// lastAPIError returns the error message from the most recent failed call
+func (c *Cache) lastAPIError() string {
+	return c.backend.LastAPIError()
+}
+
+// getStats returns cache statistics
+func (c *Cache) getStats() CacheStats {
+	stats := c.backend.Stats()
+	updateCacheEntryGauges(stats.ActiveEntries, stats.ExpiredEntries)
+	return stats
+}
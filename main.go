@@ -3,13 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
 	"google.golang.org/api/option"
@@ -31,67 +33,91 @@ type ErrorResponse struct {
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status           string            `json:"status"`
-	Timestamp        string            `json:"timestamp"`
-	Uptime           string            `json:"uptime"`
-	GoogleAPIStatus  string            `json:"google_api_status"`
-	CacheStats       CacheStats        `json:"cache_stats"`
-	LastAPICall      string            `json:"last_api_call,omitempty"`
-	LastAPIError     string            `json:"last_api_error,omitempty"`
-}
-
-// CacheStats represents cache statistics
-type CacheStats struct {
-	TotalEntries   int    `json:"total_entries"`
-	ActiveEntries  int    `json:"active_entries"`
-	ExpiredEntries int    `json:"expired_entries"`
-	HitRate        string `json:"hit_rate,omitempty"`
-}
-
-// CacheEntry represents a cached response with expiration time
-type CacheEntry struct {
-	Response  VersionResponse
-	ExpiresAt time.Time
-}
-
-// Cache stores version responses by platform and offset
-type Cache struct {
-	mu            sync.RWMutex
-	entries       map[string]CacheEntry
-	hits          int64
-	misses        int64
-	lastAPICall   time.Time
-	lastAPIError  string
-	apiHealthy    bool
+	Status          string     `json:"status"`
+	Timestamp       string     `json:"timestamp"`
+	Uptime          string     `json:"uptime"`
+	GoogleAPIStatus string     `json:"google_api_status"`
+	CacheStats      CacheStats `json:"cache_stats"`
+	TLSMode         string     `json:"tls_mode"`
+	LastAPICall     string     `json:"last_api_call,omitempty"`
+	LastAPIError    string     `json:"last_api_error,omitempty"`
 }
 
 // Global cache instance
-var (
-	cache     = &Cache{
-		entries:    make(map[string]CacheEntry),
-		apiHealthy: true,
-	}
-	startTime = time.Now()
-)
+var startTime = time.Now()
 
-const cacheTTL = 24 * time.Hour
+// activeTLSSettings holds the resolved TLS configuration for the running
+// listener, read once in main and consulted by healthCheck and the mTLS
+// client-cert allowlist check.
+var activeTLSSettings tlsSettings
 
 func main() {
-	http.HandleFunc("/api/chrome/version", getChromeVersions)
+	activeTLSSettings = loadTLSSettings()
+
+	http.HandleFunc("/api/chrome/version", requireAllowedClientCert(activeTLSSettings, getChromeVersions))
+	http.HandleFunc("/api/chrome/version/stream", handleVersionStream)
+	http.HandleFunc("/api/subscriptions", requireAllowedClientCert(activeTLSSettings, handleSubscriptions))
 	http.HandleFunc("/health", healthCheck)
+	http.Handle("/metrics", metricsHandler())
 
-	// Start cache cleanup goroutine
-	go cleanupExpiredCache()
+	// Start cache cleanup and stale-while-revalidate warming goroutines,
+	// stopped cleanly via backgroundCtx on shutdown.
+	backgroundCtx, stopBackgroundWork := context.WithCancel(context.Background())
+	go cleanupExpiredCache(backgroundCtx)
+	go cache.warmLoop(backgroundCtx)
+
+	tlsConfig, err := activeTLSSettings.buildTLSConfig()
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:      ":8080",
+		TLSConfig: tlsConfig,
+	}
 
-	port := ":8080"
-	log.Printf("Server started on http://localhost%s", port)
+	scheme := "http"
+	if activeTLSSettings.Enabled() {
+		scheme = "https"
+	}
+	log.Printf("Server started on %s://localhost%s", scheme, server.Addr)
 	log.Printf("Endpoints:")
-	log.Printf("  - GET /api/chrome/version?platform=win64&offset=10")
+	log.Printf("  - GET /api/chrome/version?platform=win64&channel=stable&offset=10")
+	log.Printf("  - GET /api/chrome/version/stream")
+	log.Printf("  - POST /api/subscriptions")
 	log.Printf("  - GET /health")
+	log.Printf("  - GET /metrics")
 	log.Printf("VERSION_OFFSET=%s (default: 10)", getVersionOffset())
+	log.Printf("VERSION_CHANNEL=%s (default: stable)", getVersionChannel())
 	log.Printf("Use ?offset=N to override VERSION_OFFSET for a single request")
-	log.Printf("Cache TTL: 24 hours")
-	log.Fatal(http.ListenAndServe(port, nil))
+	log.Printf("Cache: fresh for %s, served stale until %s, warmed every %s", freshTTL, staleTTL, getCacheWarmInterval())
+	log.Printf("CACHE_BACKEND=%s", getCacheBackendName())
+	log.Printf("TLS mode: %s", activeTLSSettings.Mode())
+
+	go func() {
+		var serveErr error
+		if activeTLSSettings.Enabled() {
+			serveErr = server.ListenAndServeTLS(activeTLSSettings.CertFile, activeTLSSettings.KeyFile)
+		} else {
+			serveErr = server.ListenAndServe()
+		}
+		if serveErr != nil && serveErr != http.ErrServerClosed {
+			log.Fatalf("Server error: %v", serveErr)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+	log.Printf("Shutdown signal received, draining connections...")
+
+	stopBackgroundWork()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
 }
 
 // getChromeVersions handles the main request
@@ -113,6 +139,16 @@ func getChromeVersions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Get channel from query parameter or environment variable
+	channel := getChannelFromRequest(r)
+	if !isValidChannel(channel) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Invalid channel. Use: stable, beta, dev, canary, extended",
+		})
+		return
+	}
+
 	// Get offset: priority to query parameter, then environment variable
 	offset := getOffsetFromRequest(r)
 	if offset < 0 {
@@ -124,56 +160,90 @@ func getChromeVersions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check cache first
-	cacheKey := fmt.Sprintf("%s:%d", platform, offset)
+	cacheKey := fmt.Sprintf("%s:%s:%d", platform, channel, offset)
 	if cachedResponse, found := cache.Get(cacheKey); found {
 		cache.recordHit()
-		log.Printf("Cache HIT for platform=%s, offset=%d", platform, offset)
+		log.Printf("Cache HIT for platform=%s, channel=%s, offset=%d", platform, channel, offset)
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(cachedResponse)
 		return
 	}
 
 	cache.recordMiss()
-	log.Printf("Cache MISS for platform=%s, offset=%d", platform, offset)
+	log.Printf("Cache MISS for platform=%s, channel=%s, offset=%d", platform, channel, offset)
 
-	// Create Google API client
-	ctx := context.Background()
-	service, err := versionhistory.NewService(ctx, option.WithoutAuthentication())
+	// Fully stale or never cached: block on a fresh fetch, bound to the
+	// incoming request's context so client cancellation aborts retries too.
+	response, err := fetchVersionFromGoogle(r.Context(), platform, channel, offset)
 	if err != nil {
-		cache.recordAPIError(err)
+		if errors.Is(err, errNoVersionsFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "No versions found",
+			})
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{
-			Error: fmt.Sprintf("Error creating service: %v", err),
+			Error: err.Error(),
 		})
 		return
 	}
 
-	// Call Google API to get all versions
-	log.Printf("Calling Google API for platform=%s, offset=%d", platform, offset)
-	parent := fmt.Sprintf("chrome/platforms/%s/channels/stable", platform)
+	// Store in cache
+	cache.Set(cacheKey, platform, channel, offset, response)
+	log.Printf("Cached result for platform=%s, channel=%s, offset=%d (fresh for %s, stale-servable for %s)", platform, channel, offset, freshTTL, staleTTL)
+
+	// Return result
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// errNoVersionsFound is returned by fetchVersionFromGoogle when the Google
+// API responds successfully but lists no versions for the requested
+// platform/channel.
+var errNoVersionsFound = errors.New("no versions found")
+
+// fetchVersionFromGoogle calls the Google versionhistory API for the given
+// platform/channel/offset and computes the resulting VersionResponse. It is
+// shared by the request path (on a full cache miss) and the cache's
+// background refresh/warming goroutines.
+func fetchVersionFromGoogle(ctx context.Context, platform, channel string, offset int) (VersionResponse, error) {
+	service, err := versionhistory.NewService(ctx, option.WithoutAuthentication())
+	if err != nil {
+		cache.recordAPIError(err)
+		return VersionResponse{}, fmt.Errorf("error creating service: %w", err)
+	}
+
+	// Call Google API to get all versions, retrying transient failures with
+	// jittered exponential backoff.
+	log.Printf("Calling Google API for platform=%s, channel=%s, offset=%d", platform, channel, offset)
+	parent := fmt.Sprintf("chrome/platforms/%s/channels/%s", platform, channel)
 	call := service.Platforms.Channels.Versions.List(parent)
 	call = call.PageSize(1000) // Get many versions to be sure
 	call = call.OrderBy("version desc")
 
-	apiResponse, err := call.Do()
+	var apiResponse *versionhistory.ListVersionsResponse
+	err = callWithRetry(ctx, getGoogleAPIMaxRetries(), func(attempt int, retryErr error) {
+		googleAPIRetriesTotal.Inc()
+		log.Printf("Retrying Google API call (attempt %d) after error: %v", attempt, retryErr)
+	}, func() error {
+		apiCallStart := time.Now()
+		var doErr error
+		apiResponse, doErr = call.Do()
+		observeGoogleAPICall(apiCallStart)
+		return doErr
+	})
 	if err != nil {
 		cache.recordAPIError(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{
-			Error: fmt.Sprintf("Error calling API: %v", err),
-		})
-		return
+		return VersionResponse{}, fmt.Errorf("error calling API: %w", err)
 	}
 
 	// Record successful API call
 	cache.recordAPISuccess()
 
 	if len(apiResponse.Versions) == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(ErrorResponse{
-			Error: "No versions found",
-		})
-		return
+		return VersionResponse{}, errNoVersionsFound
 	}
 
 	// 1. The LATEST is the first version (highest major)
@@ -194,21 +264,12 @@ func getChromeVersions(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Latest accepted: %s", latestAccepted)
 	}
 
-	// Build response
-	response := VersionResponse{
+	return VersionResponse{
 		Latest:         latest,
 		LatestAccepted: latestAccepted,
-		Channel:        "stable",
+		Channel:        channel,
 		Platform:       platform,
-	}
-
-	// Store in cache
-	cache.Set(cacheKey, response)
-	log.Printf("Cached result for platform=%s, offset=%d (expires in 24h)", platform, offset)
-
-	// Return result
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	}, nil
 }
 
 // extractMajor extracts the major number from a version (e.g.: "143.0.7499.41" -> 143)
@@ -262,6 +323,38 @@ func isValidPlatform(platform string) bool {
 	return valid[platform]
 }
 
+// isValidChannel checks if the channel is valid
+func isValidChannel(channel string) bool {
+	valid := map[string]bool{
+		"stable":   true,
+		"beta":     true,
+		"dev":      true,
+		"canary":   true,
+		"extended": true,
+	}
+	return valid[channel]
+}
+
+// getVersionChannel reads VERSION_CHANNEL from env, default "stable"
+func getVersionChannel() string {
+	channel := os.Getenv("VERSION_CHANNEL")
+	if channel == "" {
+		return "stable"
+	}
+	return channel
+}
+
+// getChannelFromRequest reads the channel from the query parameter or
+// environment variable. Priority: 1. Query parameter "channel",
+// 2. Environment variable VERSION_CHANNEL, 3. Default "stable"
+func getChannelFromRequest(r *http.Request) string {
+	channelParam := r.URL.Query().Get("channel")
+	if channelParam != "" {
+		return channelParam
+	}
+	return getVersionChannel()
+}
+
 // getOffsetFromRequest reads the offset from query parameter or environment variable
 // Priority: 1. Query parameter "offset", 2. Environment variable VERSION_OFFSET, 3. Default 10
 func getOffsetFromRequest(r *http.Request) int {
@@ -279,57 +372,6 @@ func getOffsetFromRequest(r *http.Request) int {
 	return getVersionOffsetInt()
 }
 
-// Get retrieves a cached entry if it exists and hasn't expired
-func (c *Cache) Get(key string) (VersionResponse, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	entry, found := c.entries[key]
-	if !found {
-		return VersionResponse{}, false
-	}
-
-	// Check if expired
-	if time.Now().After(entry.ExpiresAt) {
-		return VersionResponse{}, false
-	}
-
-	return entry.Response, true
-}
-
-// Set stores a response in the cache with 24h expiration
-func (c *Cache) Set(key string, response VersionResponse) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.entries[key] = CacheEntry{
-		Response:  response,
-		ExpiresAt: time.Now().Add(cacheTTL),
-	}
-}
-
-// cleanupExpiredCache removes expired entries every hour
-func cleanupExpiredCache() {
-	ticker := time.NewTicker(1 * time.Hour)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		cache.mu.Lock()
-		now := time.Now()
-		count := 0
-		for key, entry := range cache.entries {
-			if now.After(entry.ExpiresAt) {
-				delete(cache.entries, key)
-				count++
-			}
-		}
-		if count > 0 {
-			log.Printf("Cache cleanup: removed %d expired entries", count)
-		}
-		cache.mu.Unlock()
-	}
-}
-
 // healthCheck handles the health check endpoint
 func healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -358,16 +400,17 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 		Uptime:          uptimeStr,
 		GoogleAPIStatus: cache.getAPIStatus(),
 		CacheStats:      stats,
+		TLSMode:         activeTLSSettings.Mode(),
 	}
 
 	// Add last API call time if available
-	if !cache.lastAPICall.IsZero() {
-		response.LastAPICall = cache.lastAPICall.Format(time.RFC3339)
+	if lastCall := cache.lastAPICall(); !lastCall.IsZero() {
+		response.LastAPICall = lastCall.Format(time.RFC3339)
 	}
 
 	// Add last error if present
-	if cache.lastAPIError != "" {
-		response.LastAPIError = cache.lastAPIError
+	if lastErr := cache.lastAPIError(); lastErr != "" {
+		response.LastAPIError = lastErr
 	}
 
 	// Return appropriate status code
@@ -379,85 +422,3 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(response)
 }
-
-// recordHit increments cache hit counter
-func (c *Cache) recordHit() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.hits++
-}
-
-// recordMiss increments cache miss counter
-func (c *Cache) recordMiss() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.misses++
-}
-
-// recordAPISuccess marks the API as healthy
-func (c *Cache) recordAPISuccess() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.lastAPICall = time.Now()
-	c.apiHealthy = true
-	c.lastAPIError = ""
-}
-
-// recordAPIError marks the API as unhealthy
-func (c *Cache) recordAPIError(err error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.lastAPICall = time.Now()
-	c.apiHealthy = false
-	c.lastAPIError = err.Error()
-}
-
-// isAPIHealthy returns the current API health status
-func (c *Cache) isAPIHealthy() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.apiHealthy
-}
-
-// getAPIStatus returns a human-readable API status
-func (c *Cache) getAPIStatus() string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.apiHealthy {
-		return "healthy"
-	}
-	return "unhealthy"
-}
-
-// getStats returns cache statistics
-func (c *Cache) getStats() CacheStats {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	now := time.Now()
-	active := 0
-	expired := 0
-
-	for _, entry := range c.entries {
-		if now.Before(entry.ExpiresAt) {
-			active++
-		} else {
-			expired++
-		}
-	}
-
-	stats := CacheStats{
-		TotalEntries:   len(c.entries),
-		ActiveEntries:  active,
-		ExpiredEntries: expired,
-	}
-
-	// Calculate hit rate
-	total := c.hits + c.misses
-	if total > 0 {
-		hitRate := float64(c.hits) / float64(total) * 100
-		stats.HitRate = fmt.Sprintf("%.2f%%", hitRate)
-	}
-
-	return stats
-}
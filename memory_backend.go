@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// memoryBackend is the default CacheBackend: an in-process map guarded by
+// a mutex. Stats are per-process and are not shared across replicas; use
+// redisBackend for that.
+type memoryBackend struct {
+	mu                sync.RWMutex
+	entries           map[string]CacheEntry
+	hits              int64
+	misses            int64
+	staleServed       int64
+	backgroundRefresh int64
+	lastAPICallAt     time.Time
+	lastAPIErrorMsg   string
+	apiHealthy        bool
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{
+		entries:    make(map[string]CacheEntry),
+		apiHealthy: true,
+	}
+}
+
+func (b *memoryBackend) Get(key string) (CacheEntry, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	entry, found := b.entries[key]
+	return entry, found
+}
+
+func (b *memoryBackend) Set(key string, entry CacheEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[key] = entry
+}
+
+func (b *memoryBackend) Keys() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	keys := make([]string, 0, len(b.entries))
+	for key := range b.entries {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (b *memoryBackend) Cleanup() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	count := 0
+	for key, entry := range b.entries {
+		if now.After(entry.StaleUntil) {
+			delete(b.entries, key)
+			count++
+		}
+	}
+	return count
+}
+
+func (b *memoryBackend) RecordHit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hits++
+}
+
+func (b *memoryBackend) RecordMiss() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.misses++
+}
+
+func (b *memoryBackend) RecordStaleServed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.staleServed++
+}
+
+func (b *memoryBackend) RecordBackgroundRefresh() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.backgroundRefresh++
+}
+
+func (b *memoryBackend) RecordAPISuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastAPICallAt = time.Now()
+	b.apiHealthy = true
+	b.lastAPIErrorMsg = ""
+}
+
+func (b *memoryBackend) RecordAPIError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastAPICallAt = time.Now()
+	b.apiHealthy = false
+	b.lastAPIErrorMsg = err.Error()
+}
+
+func (b *memoryBackend) IsAPIHealthy() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.apiHealthy
+}
+
+func (b *memoryBackend) APIStatus() string {
+	if b.IsAPIHealthy() {
+		return "healthy"
+	}
+	return "unhealthy"
+}
+
+func (b *memoryBackend) LastAPICall() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastAPICallAt
+}
+
+func (b *memoryBackend) LastAPIError() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastAPIErrorMsg
+}
+
+func (b *memoryBackend) Stats() CacheStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := time.Now()
+	active := 0
+	expired := 0
+	byChannel := make(map[string]int)
+
+	for _, entry := range b.entries {
+		if now.Before(entry.StaleUntil) {
+			active++
+		} else {
+			expired++
+		}
+		byChannel[entry.Response.Channel]++
+	}
+
+	stats := CacheStats{
+		TotalEntries:           len(b.entries),
+		ActiveEntries:          active,
+		ExpiredEntries:         expired,
+		ByChannel:              byChannel,
+		StaleServedTotal:       b.staleServed,
+		BackgroundRefreshTotal: b.backgroundRefresh,
+	}
+
+	total := b.hits + b.misses
+	if total > 0 {
+		hitRate := float64(b.hits) / float64(total) * 100
+		stats.HitRate = fmt.Sprintf("%.2f%%", hitRate)
+	}
+
+	return stats
+}
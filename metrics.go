@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics for cache and Google API observability.
+//
+// These are updated from the same code paths (the Cache.record* methods)
+// that update the CacheBackend counters backing HealthResponse/CacheStats.
+// The Prometheus counters are per process; with the Redis backend the
+// health counters are shared by all replicas.
+var (
+	cacheEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chrome_proxy_cache_events_total",
+		Help: "Count of cache events by type.",
+	}, []string{"event"})
+
+	cacheEntriesGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "chrome_proxy_cache_entries",
+		Help: "Number of cache entries by state.",
+	}, []string{"state"})
+
+	googleAPIDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "chrome_proxy_google_api_duration_seconds",
+		Help:    "Latency of Google versionhistory API calls.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	googleAPIErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chrome_proxy_google_api_errors_total",
+		Help: "Count of failed Google versionhistory API calls.",
+	})
+
+	googleAPIRetriesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chrome_proxy_google_api_retries_total",
+		Help: "Count of retry attempts against the Google versionhistory API.",
+	})
+)
+
+// observeGoogleAPICall records the duration of a Google API call for the
+// chrome_proxy_google_api_duration_seconds histogram.
+func observeGoogleAPICall(start time.Time) {
+	googleAPIDuration.Observe(time.Since(start).Seconds())
+}
+
+// updateCacheEntryGauges refreshes the chrome_proxy_cache_entries gauge
+// from the current active/expired entry counts.
+func updateCacheEntryGauges(active, expired int) {
+	cacheEntriesGauge.WithLabelValues("active").Set(float64(active))
+	cacheEntriesGauge.WithLabelValues("expired").Set(float64(expired))
+}
+
+// metricsHandler wraps promhttp.Handler, refreshing the
+// chrome_proxy_cache_entries gauge from current backend state before
+// serving. cache.getStats() is otherwise only reached via /health, so a
+// Prometheus scraper hitting /metrics directly would see this gauge stuck
+// at zero instead of live numbers.
+func metricsHandler() http.Handler {
+	next := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cache.getStats()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// eventBufferSize bounds the central dispatch channel; Publish drops events
+// rather than blocking callers if it's ever full.
+const eventBufferSize = 256
+
+// sseClientBufferSize bounds each SSE client's per-connection channel.
+const sseClientBufferSize = 32
+
+// VersionChangeEvent is emitted whenever a background cache refresh
+// observes a change in LatestAccepted for a given (platform, channel,
+// offset) tuple.
+type VersionChangeEvent struct {
+	Platform   string `json:"platform"`
+	Channel    string `json:"channel"`
+	Offset     int    `json:"offset"`
+	Previous   string `json:"previous"`
+	Current    string `json:"current"`
+	DetectedAt string `json:"detected_at"`
+}
+
+// notifyHub fans version-change events out to webhook subscribers and SSE
+// clients. It is fed by Cache's background refresh path and drained by a
+// single dispatch goroutine.
+type notifyHub struct {
+	events chan VersionChangeEvent
+
+	mu  sync.RWMutex
+	sse map[chan VersionChangeEvent]struct{}
+}
+
+// Global notification hub
+var notifier = newNotifyHub()
+
+func newNotifyHub() *notifyHub {
+	h := &notifyHub{
+		events: make(chan VersionChangeEvent, eventBufferSize),
+		sse:    make(map[chan VersionChangeEvent]struct{}),
+	}
+	go h.dispatchLoop()
+	return h
+}
+
+// Publish enqueues an event for delivery. It never blocks the caller: if
+// the buffer is full the event is dropped and logged. A dropped event is
+// not sent again: the refresh that published it still stores the new
+// value, so later refreshes see no change.
+func (h *notifyHub) Publish(event VersionChangeEvent) {
+	select {
+	case h.events <- event:
+	default:
+		log.Printf("Notify: event buffer full, dropping change for %s/%s offset=%d", event.Platform, event.Channel, event.Offset)
+	}
+}
+
+func (h *notifyHub) dispatchLoop() {
+	for event := range h.events {
+		deliverWebhooks(event)
+		h.broadcastSSE(event)
+	}
+}
+
+// subscribeSSE registers a new SSE client and returns its event channel
+// along with an unsubscribe func.
+func (h *notifyHub) subscribeSSE() (chan VersionChangeEvent, func()) {
+	ch := make(chan VersionChangeEvent, sseClientBufferSize)
+	h.mu.Lock()
+	h.sse[ch] = struct{}{}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		delete(h.sse, ch)
+		h.mu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+// broadcastSSE fans an event out to every connected SSE client. Slow
+// clients don't block the broadcaster: if a client's buffer is full, the
+// oldest queued event is dropped to make room for the new one.
+func (h *notifyHub) broadcastSSE(event VersionChangeEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.sse {
+		select {
+		case ch <- event:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
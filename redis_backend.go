@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces every key this proxy writes to Redis.
+const redisKeyPrefix = "chrome-proxy:v1:"
+
+// redisBackend is a CacheBackend that stores entries and counters in Redis
+// via REDIS_ADDR/REDIS_PASSWORD/REDIS_DB, so horizontally scaled replicas
+// share a single cache and hit/miss view instead of each duplicating calls
+// to Google's API.
+type redisBackend struct {
+	client *redis.Client
+}
+
+func newRedisBackend() *redisBackend {
+	db, err := strconv.Atoi(os.Getenv("REDIS_DB"))
+	if err != nil {
+		db = 0
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+		DB:       db,
+	})
+	return &redisBackend{client: client}
+}
+
+func (b *redisBackend) entryKey(key string) string {
+	return redisKeyPrefix + key
+}
+
+func (b *redisBackend) Get(key string) (CacheEntry, bool) {
+	raw, err := b.client.Get(context.Background(), b.entryKey(key)).Bytes()
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (b *redisBackend) Set(key string, entry CacheEntry) {
+	ctx := context.Background()
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	b.client.Set(ctx, b.entryKey(key), raw, staleTTL)
+	b.client.SAdd(ctx, redisKeyPrefix+"keys", key)
+}
+
+// Keys returns every key this replica (or another one) has written,
+// pruning members whose entry Redis has already expired.
+func (b *redisBackend) Keys() []string {
+	ctx := context.Background()
+	keys, err := b.client.SMembers(ctx, redisKeyPrefix+"keys").Result()
+	if err != nil {
+		return nil
+	}
+	live := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if exists, _ := b.client.Exists(ctx, b.entryKey(key)).Result(); exists == 1 {
+			live = append(live, key)
+		} else {
+			b.client.SRem(ctx, redisKeyPrefix+"keys", key)
+		}
+	}
+	return live
+}
+
+// Cleanup is a no-op: entries carry their own TTL via SET EX and Redis
+// expires them itself.
+func (b *redisBackend) Cleanup() int {
+	return 0
+}
+
+func (b *redisBackend) RecordHit() {
+	b.client.Incr(context.Background(), redisKeyPrefix+"stats:hits")
+}
+
+func (b *redisBackend) RecordMiss() {
+	b.client.Incr(context.Background(), redisKeyPrefix+"stats:misses")
+}
+
+func (b *redisBackend) RecordStaleServed() {
+	b.client.Incr(context.Background(), redisKeyPrefix+"stats:stale_served")
+}
+
+func (b *redisBackend) RecordBackgroundRefresh() {
+	b.client.Incr(context.Background(), redisKeyPrefix+"stats:background_refresh")
+}
+
+func (b *redisBackend) RecordAPISuccess() {
+	ctx := context.Background()
+	b.client.Set(ctx, redisKeyPrefix+"stats:last_api_call", time.Now().Format(time.RFC3339), 0)
+	b.client.Set(ctx, redisKeyPrefix+"stats:api_healthy", "1", 0)
+	b.client.Del(ctx, redisKeyPrefix+"stats:last_api_error")
+}
+
+func (b *redisBackend) RecordAPIError(err error) {
+	ctx := context.Background()
+	b.client.Set(ctx, redisKeyPrefix+"stats:last_api_call", time.Now().Format(time.RFC3339), 0)
+	b.client.Set(ctx, redisKeyPrefix+"stats:api_healthy", "0", 0)
+	b.client.Set(ctx, redisKeyPrefix+"stats:last_api_error", err.Error(), 0)
+}
+
+func (b *redisBackend) IsAPIHealthy() bool {
+	v, err := b.client.Get(context.Background(), redisKeyPrefix+"stats:api_healthy").Result()
+	if err != nil {
+		return true
+	}
+	return v == "1"
+}
+
+func (b *redisBackend) APIStatus() string {
+	if b.IsAPIHealthy() {
+		return "healthy"
+	}
+	return "unhealthy"
+}
+
+func (b *redisBackend) LastAPICall() time.Time {
+	v, err := b.client.Get(context.Background(), redisKeyPrefix+"stats:last_api_call").Result()
+	if err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (b *redisBackend) LastAPIError() string {
+	v, _ := b.client.Get(context.Background(), redisKeyPrefix+"stats:last_api_error").Result()
+	return v
+}
+
+func (b *redisBackend) Stats() CacheStats {
+	ctx := context.Background()
+	keys := b.Keys()
+
+	byChannel := make(map[string]int)
+	active := 0
+	for _, key := range keys {
+		entry, found := b.Get(key)
+		if !found {
+			continue
+		}
+		active++
+		byChannel[entry.Response.Channel]++
+	}
+
+	hits, _ := b.client.Get(ctx, redisKeyPrefix+"stats:hits").Int64()
+	misses, _ := b.client.Get(ctx, redisKeyPrefix+"stats:misses").Int64()
+	staleServed, _ := b.client.Get(ctx, redisKeyPrefix+"stats:stale_served").Int64()
+	backgroundRefresh, _ := b.client.Get(ctx, redisKeyPrefix+"stats:background_refresh").Int64()
+
+	stats := CacheStats{
+		TotalEntries:           len(keys),
+		ActiveEntries:          active,
+		ExpiredEntries:         len(keys) - active,
+		ByChannel:              byChannel,
+		StaleServedTotal:       staleServed,
+		BackgroundRefreshTotal: backgroundRefresh,
+	}
+
+	total := hits + misses
+	if total > 0 {
+		hitRate := float64(hits) / float64(total) * 100
+		stats.HitRate = fmt.Sprintf("%.2f%%", hitRate)
+	}
+
+	return stats
+}
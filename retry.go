@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	retryInitialInterval = 200 * time.Millisecond
+	retryMultiplier      = 1.5
+	retryJitterFraction  = 0.2
+	retryMaxElapsedTime  = 30 * time.Second
+	defaultMaxRetries    = 5
+)
+
+// getGoogleAPIMaxRetries reads GOOGLE_API_MAX_RETRIES from env, default 5.
+func getGoogleAPIMaxRetries() int {
+	raw := os.Getenv("GOOGLE_API_MAX_RETRIES")
+	if raw == "" {
+		return defaultMaxRetries
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return defaultMaxRetries
+	}
+	return n
+}
+
+// isRetryableError reports whether err is a transient failure worth
+// retrying: a context deadline, a timeout-flagged net.Error, or a
+// *googleapi.Error with a 429/500/502/503/504 status. A canceled context and other
+// errors (4xx auth/validation, permanent client-side failures) fail fast.
+//
+// net/http wraps virtually every transport failure in *url.Error, which
+// implements net.Error, so a blanket errors.As(err, &netErr) match would
+// also catch context.Canceled and non-transient failures surfaced through
+// the same type. Check Timeout() explicitly instead of matching on type
+// alone, and exclude context.Canceled up front in case it reaches here
+// through some other wrapper.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case 429, 500, 502, 503, 504:
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// callWithRetry retries fn with jittered exponential backoff, honoring
+// ctx cancellation and the GOOGLE_API_MAX_RETRIES cap. Each attempt after
+// the first is reported via onRetry so callers can record a metrics
+// event per retry.
+func callWithRetry(ctx context.Context, maxRetries int, onRetry func(attempt int, err error), fn func() error) error {
+	start := time.Now()
+	interval := retryInitialInterval
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+		if !isRetryableError(lastErr) {
+			return lastErr
+		}
+		if attempt >= maxRetries {
+			return lastErr
+		}
+		if time.Since(start) >= retryMaxElapsedTime {
+			return lastErr
+		}
+
+		if onRetry != nil {
+			onRetry(attempt+1, lastErr)
+		}
+
+		jitter := 1 + retryJitterFraction*(2*rand.Float64()-1)
+		sleep := time.Duration(float64(interval) * jitter)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+
+		interval = time.Duration(float64(interval) * retryMultiplier)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+// fakeNetError is a minimal net.Error for exercising the Timeout() branch
+// of isRetryableError without depending on a real socket failure.
+type fakeNetError struct {
+	msg     string
+	timeout bool
+}
+
+func (e *fakeNetError) Error() string   { return e.msg }
+func (e *fakeNetError) Timeout() bool   { return e.timeout }
+func (e *fakeNetError) Temporary() bool { return e.timeout }
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil error", nil, false},
+		{"deadline exceeded", context.DeadlineExceeded, true},
+		{
+			"wrapped deadline exceeded",
+			fmt.Errorf("calling google api: %w", context.DeadlineExceeded),
+			true,
+		},
+		{"canceled context", context.Canceled, false},
+		{
+			"url.Error wrapping canceled context",
+			&url.Error{Op: "Get", URL: "https://example.com", Err: context.Canceled},
+			false,
+		},
+		{
+			"timeout net error wrapped in url.Error",
+			&url.Error{Op: "Get", URL: "https://example.com", Err: &fakeNetError{msg: "i/o timeout", timeout: true}},
+			true,
+		},
+		{
+			"non-timeout net error wrapped in url.Error",
+			&url.Error{Op: "Get", URL: "https://example.com", Err: &fakeNetError{msg: "connection refused", timeout: false}},
+			false,
+		},
+		{"googleapi 429", &googleapi.Error{Code: 429}, true},
+		{"googleapi 500", &googleapi.Error{Code: 500}, true},
+		{"googleapi 503", &googleapi.Error{Code: 503}, true},
+		{"googleapi 404", &googleapi.Error{Code: 404}, false},
+		{"googleapi 401", &googleapi.Error{Code: 401}, false},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// handleVersionStream implements GET /api/chrome/version/stream, an SSE
+// endpoint emitting a VersionChangeEvent whenever the cache warmer detects
+// a change in LatestAccepted.
+func handleVersionStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events, unsubscribe := notifier.subscribeSSE()
+	defer unsubscribe()
+
+	log.Printf("SSE client connected from %s", r.RemoteAddr)
+	defer log.Printf("SSE client disconnected from %s", r.RemoteAddr)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// tlsClientAuthMode mirrors the TLS_CLIENT_AUTH env values.
+type tlsClientAuthMode string
+
+const (
+	clientAuthNone    tlsClientAuthMode = "none"
+	clientAuthRequire tlsClientAuthMode = "require"
+	clientAuthVerify  tlsClientAuthMode = "verify"
+)
+
+// tlsSettings captures the resolved TLS/mTLS configuration for the
+// listener, read once at startup from TLS_CERT_FILE, TLS_KEY_FILE,
+// TLS_CLIENT_CA_FILE, TLS_CLIENT_AUTH and TLS_CLIENT_ALLOWED_CNS.
+type tlsSettings struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	ClientAuth   tlsClientAuthMode
+	AllowedCNs   map[string]bool
+}
+
+func loadTLSSettings() tlsSettings {
+	auth := tlsClientAuthMode(os.Getenv("TLS_CLIENT_AUTH"))
+	switch auth {
+	case clientAuthRequire, clientAuthVerify:
+	default:
+		auth = clientAuthNone
+	}
+
+	allowed := make(map[string]bool)
+	for _, cn := range strings.Split(os.Getenv("TLS_CLIENT_ALLOWED_CNS"), ",") {
+		cn = strings.TrimSpace(cn)
+		if cn != "" {
+			allowed[cn] = true
+		}
+	}
+
+	return tlsSettings{
+		CertFile:     os.Getenv("TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile: os.Getenv("TLS_CLIENT_CA_FILE"),
+		ClientAuth:   auth,
+		AllowedCNs:   allowed,
+	}
+}
+
+// Enabled reports whether the listener should use TLS at all.
+func (s tlsSettings) Enabled() bool {
+	return s.CertFile != "" && s.KeyFile != ""
+}
+
+// Mode returns a human-readable description for the health endpoint.
+func (s tlsSettings) Mode() string {
+	if !s.Enabled() {
+		return "disabled"
+	}
+	if s.ClientAuth == clientAuthNone {
+		return "tls"
+	}
+	return fmt.Sprintf("mtls-%s", s.ClientAuth)
+}
+
+// buildTLSConfig builds a *tls.Config implementing the configured client
+// certificate requirements, or nil if TLS is disabled.
+func (s tlsSettings) buildTLSConfig() (*tls.Config, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{}
+	if s.ClientAuth == clientAuthNone {
+		return cfg, nil
+	}
+
+	caCert, err := os.ReadFile(s.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading TLS_CLIENT_CA_FILE: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in TLS_CLIENT_CA_FILE")
+	}
+	cfg.ClientCAs = pool
+
+	if s.ClientAuth == clientAuthRequire {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return cfg, nil
+}
+
+// isClientCertAllowed reports whether r's peer certificate CN or any SAN
+// is in the TLS_CLIENT_ALLOWED_CNS allowlist. Only enforced when
+// TLS_CLIENT_AUTH=require and an allowlist is configured; otherwise every
+// request is allowed through.
+func (s tlsSettings) isClientCertAllowed(r *http.Request) bool {
+	if s.ClientAuth != clientAuthRequire || len(s.AllowedCNs) == 0 {
+		return true
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+	if s.AllowedCNs[cert.Subject.CommonName] {
+		return true
+	}
+	for _, name := range cert.DNSNames {
+		if s.AllowedCNs[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAllowedClientCert wraps next, rejecting requests whose peer
+// certificate isn't in settings' allowlist.
+func requireAllowedClientCert(settings tlsSettings, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !settings.isClientCertAllowed(r) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "Client certificate not authorized",
+			})
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+)
+
+// requestWithCert builds an *http.Request carrying cert as the sole peer
+// certificate, as http.Server populates r.TLS for an authenticated mTLS
+// connection.
+func requestWithCert(cert *x509.Certificate) *http.Request {
+	return &http.Request{
+		TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+	}
+}
+
+func certWithNames(cn string, sans ...string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:  pkix.Name{CommonName: cn},
+		DNSNames: sans,
+	}
+}
+
+func TestIsClientCertAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings tlsSettings
+		req      *http.Request
+		want     bool
+	}{
+		{
+			name:     "auth not required",
+			settings: tlsSettings{ClientAuth: clientAuthNone, AllowedCNs: map[string]bool{"svc-a": true}},
+			req:      &http.Request{},
+			want:     true,
+		},
+		{
+			name:     "required but no allowlist configured",
+			settings: tlsSettings{ClientAuth: clientAuthRequire, AllowedCNs: map[string]bool{}},
+			req:      &http.Request{},
+			want:     true,
+		},
+		{
+			name:     "required, allowlist set, no TLS on request",
+			settings: tlsSettings{ClientAuth: clientAuthRequire, AllowedCNs: map[string]bool{"svc-a": true}},
+			req:      &http.Request{},
+			want:     false,
+		},
+		{
+			name:     "required, allowlist set, no peer certificates",
+			settings: tlsSettings{ClientAuth: clientAuthRequire, AllowedCNs: map[string]bool{"svc-a": true}},
+			req:      &http.Request{TLS: &tls.ConnectionState{}},
+			want:     false,
+		},
+		{
+			name:     "CN matches allowlist",
+			settings: tlsSettings{ClientAuth: clientAuthRequire, AllowedCNs: map[string]bool{"svc-a": true}},
+			req:      requestWithCert(certWithNames("svc-a")),
+			want:     true,
+		},
+		{
+			name:     "CN does not match, SAN does",
+			settings: tlsSettings{ClientAuth: clientAuthRequire, AllowedCNs: map[string]bool{"svc-b.internal": true}},
+			req:      requestWithCert(certWithNames("svc-a", "svc-b.internal")),
+			want:     true,
+		},
+		{
+			name:     "neither CN nor SAN matches",
+			settings: tlsSettings{ClientAuth: clientAuthRequire, AllowedCNs: map[string]bool{"svc-b": true}},
+			req:      requestWithCert(certWithNames("svc-a", "svc-a.internal")),
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.isClientCertAllowed(tt.req); got != tt.want {
+				t.Errorf("isClientCertAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	webhookMaxAttempts     = 5
+	webhookInitialInterval = 500 * time.Millisecond
+	webhookMultiplier      = 2.0
+	webhookJitterFraction  = 0.2
+	webhookRequestTimeout  = 10 * time.Second
+)
+
+// webhookSubscribers holds every URL registered to receive version-change
+// notifications, seeded from WEBHOOK_URLS and grown via POST
+// /api/subscriptions.
+var webhookSubscribers = newWebhookSubscriberList()
+
+type webhookSubscriberList struct {
+	mu   sync.RWMutex
+	urls map[string]struct{}
+}
+
+func newWebhookSubscriberList() *webhookSubscriberList {
+	list := &webhookSubscriberList{urls: make(map[string]struct{})}
+	for _, url := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		url = strings.TrimSpace(url)
+		if url != "" {
+			list.urls[url] = struct{}{}
+		}
+	}
+	return list
+}
+
+func (l *webhookSubscriberList) Add(url string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.urls[url] = struct{}{}
+}
+
+func (l *webhookSubscriberList) Snapshot() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	urls := make([]string, 0, len(l.urls))
+	for url := range l.urls {
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// subscriptionRequest is the POST /api/subscriptions body.
+type subscriptionRequest struct {
+	URL string `json:"url"`
+}
+
+// validateWebhookURL rejects URLs that would turn deliverWebhook into an
+// SSRF primitive: non-http(s) schemes, and hosts that resolve to loopback,
+// link-local, or other private-range addresses (cloud metadata endpoints
+// included, since those are link-local too).
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("not a valid URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	host := parsed.Hostname()
+	if host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if strings.EqualFold(host, "localhost") {
+		return fmt.Errorf("host %q is not allowed", host)
+	}
+
+	ips, err := lookupWebhookHost(host)
+	if err != nil {
+		return fmt.Errorf("resolving host: %w", err)
+	}
+	for _, ip := range ips {
+		if isDisallowedWebhookIP(ip) {
+			return fmt.Errorf("host %q resolves to a non-routable or private address", host)
+		}
+	}
+	return nil
+}
+
+// lookupWebhookHost resolves host to its IPs, treating an IP literal as
+// its own single-element result.
+func lookupWebhookHost(host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	return net.LookupIP(host)
+}
+
+// isDisallowedWebhookIP reports whether ip falls in a range that must
+// never receive webhook deliveries: loopback, link-local (this covers
+// cloud metadata endpoints like 169.254.169.254), or RFC 1918/4193
+// private space.
+func isDisallowedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() ||
+		ip.IsPrivate()
+}
+
+// handleSubscriptions registers a webhook URL to receive version-change
+// notifications.
+func handleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	var req subscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error: `Body must include a non-empty "url"`,
+		})
+		return
+	}
+
+	if err := validateWebhookURL(req.URL); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error: fmt.Sprintf("Invalid webhook url: %v", err),
+		})
+		return
+	}
+
+	webhookSubscribers.Add(req.URL)
+	log.Printf("Registered webhook subscriber: %s", req.URL)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "subscribed",
+		"url":    req.URL,
+	})
+}
+
+// deliverWebhooks POSTs event to every registered subscriber, signing the
+// payload with WEBHOOK_SECRET and retrying 5xx responses with exponential
+// backoff.
+func deliverWebhooks(event VersionChangeEvent) {
+	urls := webhookSubscribers.Snapshot()
+	if len(urls) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Webhook: failed to marshal event: %v", err)
+		return
+	}
+	signature := signWebhookPayload(payload)
+
+	for _, url := range urls {
+		go deliverWebhook(url, payload, signature)
+	}
+}
+
+// signWebhookPayload computes the HMAC-SHA256 of payload using
+// WEBHOOK_SECRET, hex-encoded for the X-Chrome-Proxy-Signature header.
+func signWebhookPayload(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("WEBHOOK_SECRET")))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook POSTs payload to url, retrying 5xx responses and network
+// errors up to webhookMaxAttempts times with jittered exponential backoff.
+func deliverWebhook(url string, payload []byte, signature string) {
+	client := &http.Client{Timeout: webhookRequestTimeout}
+	interval := webhookInitialInterval
+
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			log.Printf("Webhook: failed to build request for %s: %v", url, err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Chrome-Proxy-Signature", signature)
+
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 500 {
+				return
+			}
+		}
+
+		if attempt == webhookMaxAttempts {
+			log.Printf("Webhook: giving up on %s after %d attempts", url, attempt)
+			return
+		}
+
+		jitter := 1 + webhookJitterFraction*(2*rand.Float64()-1)
+		time.Sleep(time.Duration(float64(interval) * jitter))
+		interval = time.Duration(float64(interval) * webhookMultiplier)
+	}
+}